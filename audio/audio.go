@@ -0,0 +1,113 @@
+// Package audio generates waveform images and extracts embedded cover art
+// from audio files, for use as preview thumbnails.
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// WaveformOptions controls waveform image rendering.
+type WaveformOptions struct {
+	// Width and Height size the output. Zero defaults to 640x120.
+	Width, Height int
+	// Color is an ffmpeg color spec (e.g. "white" or "0x4A90D9"). Empty
+	// defaults to "white", suited to a small monochrome thumb waveform;
+	// callers wanting a full-width color waveform for "big" previews should
+	// set an explicit color.
+	Color string
+}
+
+// Previewer generates preview artifacts for an audio file identified by its
+// real filesystem path.
+type Previewer interface {
+	// Waveform renders a PNG waveform image.
+	Waveform(ctx context.Context, path string, opts WaveformOptions) ([]byte, error)
+	// CoverArt extracts embedded cover art, if any. ok is false (with a nil
+	// error) when the file simply has no embedded art.
+	CoverArt(ctx context.Context, path string) (data []byte, ok bool, err error)
+}
+
+// FFmpegPreviewer shells out to the system ffmpeg binary.
+type FFmpegPreviewer struct {
+	// Bin is the ffmpeg executable to invoke. Defaults to "ffmpeg" when empty.
+	Bin string
+}
+
+// NewFFmpegPreviewer returns a Previewer backed by the given ffmpeg binary.
+// An empty bin defaults to "ffmpeg" on PATH.
+func NewFFmpegPreviewer(bin string) *FFmpegPreviewer {
+	return &FFmpegPreviewer{Bin: bin}
+}
+
+func (t *FFmpegPreviewer) bin() string {
+	if t.Bin == "" {
+		return "ffmpeg"
+	}
+	return t.Bin
+}
+
+func (t *FFmpegPreviewer) Waveform(ctx context.Context, path string, opts WaveformOptions) ([]byte, error) {
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = 640
+	}
+	if height == 0 {
+		height = 120
+	}
+	color := opts.Color
+	if color == "" {
+		color = "white"
+	}
+
+	tmpFile, err := os.CreateTemp("", "waveform-*.png")
+	if err != nil {
+		return nil, err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	filter := fmt.Sprintf("showwavespic=s=%dx%d:colors=%s", width, height, color)
+	cmd := exec.CommandContext(ctx, t.bin(), "-y", "-i", path, "-filter_complex", filter, "-frames:v", "1", tmpFile.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: waveform: %w: %s", err, output)
+	}
+
+	return os.ReadFile(tmpFile.Name())
+}
+
+func (t *FFmpegPreviewer) CoverArt(ctx context.Context, path string) ([]byte, bool, error) {
+	tmpFile, err := os.CreateTemp("", "cover-*.jpg")
+	if err != nil {
+		return nil, false, err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	cmd := exec.CommandContext(ctx, t.bin(), "-y", "-i", path, "-an", "-vcodec", "copy", tmpFile.Name())
+	if _, err := cmd.CombinedOutput(); err != nil {
+		// Most audio files carry no embedded art; that's not a failure.
+		return nil, false, nil
+	}
+
+	data, err := os.ReadFile(tmpFile.Name())
+	if err != nil || len(data) == 0 {
+		return nil, false, nil
+	}
+
+	return data, true, nil
+}
+
+// NoopPreviewer never generates previews; it exists so administrators can
+// disable audio thumbnailing entirely without changing call sites.
+type NoopPreviewer struct{}
+
+func (NoopPreviewer) Waveform(context.Context, string, WaveformOptions) ([]byte, error) {
+	return nil, fmt.Errorf("audio preview disabled")
+}
+
+func (NoopPreviewer) CoverArt(context.Context, string) ([]byte, bool, error) {
+	return nil, false, nil
+}
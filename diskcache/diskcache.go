@@ -0,0 +1,311 @@
+// Package diskcache is a bounded, on-disk FileCache implementation for
+// preview thumbnails. Entries are addressed by caller-supplied keys (see
+// http.previewCacheKey and its content-hash variant), indexed in memory with
+// an LRU+TTL policy. Entries past the size limit are evicted by Store as it
+// writes; entries past the age limit are evicted by a background sweeper.
+//
+// Options.MaxSize and Options.MaxAge are meant to be sourced from CLI flags
+// and the settings JSON by the caller that constructs the Cache.
+package diskcache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options configures eviction behavior.
+type Options struct {
+	// MaxSize is the maximum total size in bytes the cache may occupy on
+	// disk. Zero means unbounded.
+	MaxSize int64
+	// MaxAge is how long an entry may go unaccessed before it becomes
+	// eligible for eviction. Zero means entries never expire by age.
+	MaxAge time.Duration
+	// SweepInterval is how often the background sweeper checks for expired
+	// entries. Defaults to one minute.
+	SweepInterval time.Duration
+}
+
+// Stats is a point-in-time snapshot of cache activity, served by
+// GET /api/cache/stats.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type entry struct {
+	key        string
+	size       int64
+	storedAt   time.Time
+	accessedAt time.Time
+}
+
+// Cache is a disk-backed FileCache bounded by total size and entry age.
+type Cache struct {
+	dir string
+	opt Options
+
+	mu      sync.Mutex
+	index   map[string]*list.Element // key -> element holding *entry
+	lru     *list.List               // front = most recently used
+	curSize int64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+
+	stopSweep chan struct{}
+}
+
+// New creates a Cache rooted at dir, creating it if necessary, and starts a
+// background sweeper that enforces opt.MaxAge.
+func New(dir string, opt Options) (*Cache, error) {
+	if opt.SweepInterval == 0 {
+		opt.SweepInterval = time.Minute
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		dir:       dir,
+		opt:       opt,
+		index:     map[string]*list.Element{},
+		lru:       list.New(),
+		stopSweep: make(chan struct{}),
+	}
+
+	if err := c.loadExisting(); err != nil {
+		return nil, err
+	}
+
+	go c.sweepLoop()
+
+	return c, nil
+}
+
+// Close stops the background sweeper. It does not delete any cached files.
+func (c *Cache) Close() error {
+	close(c.stopSweep)
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+func (c *Cache) loadExisting() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, de := range entries {
+		if de.IsDir() {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		c.pushFrontLocked(&entry{
+			key:        de.Name(),
+			size:       info.Size(),
+			storedAt:   info.ModTime(),
+			accessedAt: info.ModTime(),
+		})
+	}
+
+	return nil
+}
+
+func (c *Cache) pushFrontLocked(e *entry) {
+	el := c.lru.PushFront(e)
+	c.index[e.key] = el
+	c.curSize += e.size
+}
+
+// Store writes value under key, evicting least-recently-used entries first
+// if the write would push the cache past Options.MaxSize.
+func (c *Cache) Store(_ context.Context, key string, value []byte) error {
+	if err := os.WriteFile(c.path(key), value, 0o644); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if el, ok := c.index[key]; ok {
+		c.curSize -= el.Value.(*entry).size
+		c.lru.Remove(el)
+	}
+	now := time.Now()
+	c.pushFrontLocked(&entry{key: key, size: int64(len(value)), storedAt: now, accessedAt: now})
+	c.enforceSizeLocked()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// enforceSizeLocked evicts least-recently-used entries until the cache fits
+// within Options.MaxSize. The caller must hold c.mu.
+func (c *Cache) enforceSizeLocked() {
+	if c.opt.MaxSize <= 0 {
+		return
+	}
+	for c.curSize > c.opt.MaxSize {
+		back := c.lru.Back()
+		if back == nil {
+			return
+		}
+		c.evictLocked(back)
+	}
+}
+
+func (c *Cache) evictLocked(el *list.Element) {
+	e := el.Value.(*entry)
+	c.lru.Remove(el)
+	delete(c.index, e.key)
+	c.curSize -= e.size
+	c.evictions.Add(1)
+	_ = os.Remove(c.path(e.key))
+}
+
+// Load reads the value stored under key, marking it most-recently-used.
+func (c *Cache) Load(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	el, ok := c.index[key]
+	if ok {
+		c.lru.MoveToFront(el)
+		el.Value.(*entry).accessedAt = time.Now()
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		c.misses.Add(1)
+		return nil, false, nil
+	}
+
+	value, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		// Index and disk disagree; treat it as a miss rather than failing
+		// the request.
+		c.misses.Add(1)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.hits.Add(1)
+	return value, true, nil
+}
+
+// OpenReader opens the file backing key directly, marking it
+// most-recently-used, so callers can stream it (and let http.ServeContent
+// honor Range requests) instead of reading it fully into memory. It returns
+// an error satisfying errors.Is(err, fs.ErrNotExist) on a cache miss.
+func (c *Cache) OpenReader(_ context.Context, key string) (io.ReadSeekCloser, int64, error) {
+	c.mu.Lock()
+	el, ok := c.index[key]
+	if ok {
+		c.lru.MoveToFront(el)
+		el.Value.(*entry).accessedAt = time.Now()
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		c.misses.Add(1)
+		return nil, 0, os.ErrNotExist
+	}
+
+	f, err := os.Open(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		c.misses.Add(1)
+		return nil, 0, err
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	c.hits.Add(1)
+	return f, info.Size(), nil
+}
+
+// Delete removes key from the cache, if present.
+func (c *Cache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	if el, ok := c.index[key]; ok {
+		c.evictLocked(el)
+	}
+	c.mu.Unlock()
+
+	err := os.Remove(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Stats returns a snapshot of cache activity for the /api/cache/stats
+// endpoint.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	bytes := c.curSize
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Bytes:     bytes,
+	}
+}
+
+func (c *Cache) sweepLoop() {
+	ticker := time.NewTicker(c.opt.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.sweepExpired()
+		case <-c.stopSweep:
+			return
+		}
+	}
+}
+
+func (c *Cache) sweepExpired() {
+	if c.opt.MaxAge <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-c.opt.MaxAge)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for el := c.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if e := el.Value.(*entry); e.accessedAt.Before(cutoff) {
+			c.evictLocked(el)
+		}
+		el = prev
+	}
+}
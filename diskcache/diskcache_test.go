@@ -0,0 +1,96 @@
+package diskcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := New(t.TempDir(), Options{MaxSize: 20})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	must := func(key string, n int) {
+		if err := c.Store(ctx, key, make([]byte, n)); err != nil {
+			t.Fatalf("Store(%q): %v", key, err)
+		}
+	}
+
+	must("a", 10)
+	must("b", 10)
+
+	// Touch "a" so it's more recently used than "b", then push the cache
+	// over MaxSize: "b" should be evicted first, not "a".
+	if _, ok, err := c.Load(ctx, "a"); err != nil || !ok {
+		t.Fatalf("Load(a) = %v, %v, want hit", ok, err)
+	}
+	must("c", 10)
+
+	if _, ok, _ := c.Load(ctx, "b"); ok {
+		t.Errorf("Load(b) hit after eviction, want miss")
+	}
+	if _, ok, _ := c.Load(ctx, "a"); !ok {
+		t.Errorf("Load(a) miss, want hit (should have survived eviction)")
+	}
+	if _, ok, _ := c.Load(ctx, "c"); !ok {
+		t.Errorf("Load(c) miss, want hit")
+	}
+
+	if evictions := c.Stats().Evictions; evictions != 1 {
+		t.Errorf("Stats().Evictions = %d, want 1", evictions)
+	}
+}
+
+func TestSweepExpiredEvictsOnlyStaleEntries(t *testing.T) {
+	c, err := New(t.TempDir(), Options{MaxAge: time.Minute})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Store(ctx, "old", []byte("stale")); err != nil {
+		t.Fatalf("Store(old): %v", err)
+	}
+
+	// Backdate the entry past MaxAge without waiting on a real clock.
+	el := c.index["old"]
+	el.Value.(*entry).accessedAt = time.Now().Add(-2 * time.Minute)
+
+	if err := c.Store(ctx, "fresh", []byte("new")); err != nil {
+		t.Fatalf("Store(fresh): %v", err)
+	}
+
+	c.sweepExpired()
+
+	if _, ok, _ := c.Load(ctx, "old"); ok {
+		t.Errorf("Load(old) hit after sweep, want miss")
+	}
+	if _, ok, _ := c.Load(ctx, "fresh"); !ok {
+		t.Errorf("Load(fresh) miss after sweep, want hit")
+	}
+}
+
+func TestSweepExpiredNoopWhenMaxAgeUnset(t *testing.T) {
+	c, err := New(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Store(ctx, "key", []byte("value")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	c.index["key"].Value.(*entry).accessedAt = time.Now().Add(-24 * time.Hour)
+
+	c.sweepExpired()
+
+	if _, ok, _ := c.Load(ctx, "key"); !ok {
+		t.Errorf("Load(key) miss after no-op sweep, want hit: MaxAge=0 should disable sweeping")
+	}
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/filebrowser/filebrowser/v2/diskcache"
+)
+
+// statsReporter is implemented by FileCache backends that track usage
+// metrics, such as diskcache.Cache. Backends that don't track metrics (e.g.
+// a bare in-memory map used in tests) simply don't satisfy it.
+type statsReporter interface {
+	Stats() diskcache.Stats
+}
+
+// cacheStatsHandler serves GET /api/cache/stats with hit/miss/eviction
+// counters and current size for the configured FileCache, for admins to
+// monitor preview cache health.
+func cacheStatsHandler(fileCache FileCache) handleFunc {
+	return withAdmin(func(w http.ResponseWriter, r *http.Request, _ *data) (int, error) {
+		reporter, ok := fileCache.(statsReporter)
+		if !ok {
+			return http.StatusNotImplemented, nil
+		}
+
+		return renderJSON(w, r, reporter.Stats())
+	})
+}
@@ -6,19 +6,28 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"time"
+	"strings"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/gorilla/mux"
 
+	"github.com/filebrowser/filebrowser/v2/audio"
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/img"
+	"github.com/filebrowser/filebrowser/v2/previews"
+	"github.com/filebrowser/filebrowser/v2/rasterize"
+	"github.com/filebrowser/filebrowser/v2/video"
 )
 
+// largeFileHashThreshold is the file size above which previewCacheKey skips
+// hashing content and falls back to a size+mtime key, so a single cache miss
+// on a multi-gigabyte video doesn't mean reading it twice.
+const largeFileHashThreshold = 64 << 20 // 64 MiB
+
 /*
 ENUM(
 thumb
@@ -32,13 +41,137 @@ type ImgService interface {
 	Resize(ctx context.Context, in io.Reader, width, height int, out io.Writer, options ...img.Option) error
 }
 
+// VideoThumbnailer extracts preview images from video files. It mirrors
+// ImgService so the http package can depend on an interface rather than the
+// concrete video package, letting administrators swap or disable the
+// implementation via setup.go/config.
+type VideoThumbnailer interface {
+	Frame(ctx context.Context, path string, opts video.FrameOptions) ([]byte, error)
+	Animated(ctx context.Context, path string, opts video.AnimatedOptions) ([]byte, error)
+	Sprite(ctx context.Context, path string, opts video.SpriteOptions) (sheet, vtt []byte, err error)
+}
+
+// videoPreviewMode selects which of VideoThumbnailer's outputs a request
+// wants, via the "mode" query parameter. It defaults to a single frame.
+type videoPreviewMode string
+
+const (
+	videoModeFrame    videoPreviewMode = "frame"
+	videoModeAnimated videoPreviewMode = "animated"
+	videoModeSprite   videoPreviewMode = "sprite"
+)
+
+func parseVideoPreviewMode(raw string) (videoPreviewMode, error) {
+	switch videoPreviewMode(raw) {
+	case "":
+		return videoModeFrame, nil
+	case videoModeFrame, videoModeAnimated, videoModeSprite:
+		return videoPreviewMode(raw), nil
+	default:
+		return "", fmt.Errorf("invalid preview mode: %s", raw)
+	}
+}
+
+// DocumentRasterizer renders the first page of a document to an image. It
+// mirrors ImgService/VideoThumbnailer so the http package depends only on an
+// interface, letting administrators swap or disable the implementation via
+// setup.go/config.
+type DocumentRasterizer interface {
+	RenderFirstPage(ctx context.Context, path string, opts rasterize.Options) ([]byte, error)
+}
+
+// AudioPreviewer generates waveform images and extracts embedded cover art
+// from audio files.
+type AudioPreviewer interface {
+	Waveform(ctx context.Context, path string, opts audio.WaveformOptions) ([]byte, error)
+	CoverArt(ctx context.Context, path string) ([]byte, bool, error)
+}
+
 type FileCache interface {
 	Store(ctx context.Context, key string, value []byte) error
 	Load(ctx context.Context, key string) ([]byte, bool, error)
 	Delete(ctx context.Context, key string) error
+	// OpenReader returns a seekable stream for key's value along with its
+	// size, so callers can serve it via http.ServeContent (honoring Range
+	// requests) without buffering it into memory first. It returns an error
+	// satisfying errors.Is(err, fs.ErrNotExist) on a cache miss.
+	OpenReader(ctx context.Context, key string) (io.ReadSeekCloser, int64, error)
+}
+
+// PreviewConfig toggles which preview kinds are generated, as opposed to
+// served raw. Each flag is independent: disabling one kind doesn't disable
+// the others.
+type PreviewConfig struct {
+	EnableThumbnails bool // image thumb-size previews
+	ResizePreview    bool // image big-size previews
+	EnableDocuments  bool // PDF/Office first-page previews
+	EnableAudio      bool // waveform/cover-art previews
+}
+
+// serveCachedPreview streams the value stored under cacheKey, generating it
+// first on a cache miss. It sets a strong ETag derived from cacheKey (itself
+// content-addressed, see previewCacheKey) and short-circuits with 304 on a
+// matching If-None-Match, and it streams through FileCache.OpenReader rather
+// than buffering the payload into a []byte, so http.ServeContent can honor
+// Range requests on large "big" previews and animated WebP clips without a
+// second allocation.
+func serveCachedPreview(
+	w http.ResponseWriter,
+	r *http.Request,
+	fileCache FileCache,
+	cacheKey string,
+	file *files.FileInfo,
+	contentType string,
+	generate func() ([]byte, error),
+) (int, error) {
+	etag := `"` + cacheKey + `"`
+	w.Header().Set("Cache-Control", "private")
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return http.StatusNotModified, nil
+	}
+
+	rc, _, err := fileCache.OpenReader(r.Context(), cacheKey)
+	switch {
+	case err == nil:
+		defer rc.Close()
+		http.ServeContent(w, r, file.Name, file.ModTime, rc)
+		return http.StatusOK, nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return errToStatus(err), err
+	}
+
+	data, err := generate()
+	if err != nil {
+		return errToStatus(err), err
+	}
+	http.ServeContent(w, r, file.Name, file.ModTime, bytes.NewReader(data))
+
+	return http.StatusOK, nil
+}
+
+// priorityFor maps a requested PreviewSize onto a previews.Priority so
+// interactive thumbnail requests preempt bulk "big" ones on the shared
+// worker pool.
+func priorityFor(previewSize PreviewSize) previews.Priority {
+	if previewSize == PreviewSizeThumb {
+		return previews.PriorityInteractive
+	}
+	return previews.PriorityBulk
 }
 
-func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, resizePreview bool) handleFunc {
+func previewHandler(
+	imgSvc ImgService,
+	videoSvc VideoThumbnailer,
+	docSvc DocumentRasterizer,
+	audioSvc AudioPreviewer,
+	fileCache FileCache,
+	previewSvc *previews.Service,
+	cfg PreviewConfig,
+) handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		if !d.user.Perm.Download {
 			return http.StatusAccepted, nil
@@ -50,6 +183,11 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 			return http.StatusBadRequest, err
 		}
 
+		videoMode, err := parseVideoPreviewMode(r.URL.Query().Get("mode"))
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       "/" + vars["path"],
@@ -64,24 +202,67 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 
 		setContentDisposition(w, r, file)
 
-		switch file.Type {
-		case "image":
-			return handleImagePreview(w, r, imgSvc, fileCache, file, previewSize, enableThumbnails, resizePreview)
-		case "video":
-			return handleVideoPreview(w, r, fileCache, file, previewSize)
+		// files.FileInfo.Type has no "doc" value of its own: PDFs and Office
+		// documents come through as whatever default type the rest of the
+		// package gives non-media blobs, so documents are routed by
+		// extension (documentExtensions) rather than by file.Type.
+		switch {
+		case file.Type == "image":
+			outFormat := negotiateImageFormat(r)
+			return handleImagePreview(w, r, imgSvc, fileCache, previewSvc, file, previewSize, outFormat, cfg.EnableThumbnails, cfg.ResizePreview)
+		case file.Type == "video":
+			return handleVideoPreview(w, r, videoSvc, fileCache, previewSvc, file, previewSize, videoMode)
+		case file.Type == "audio":
+			if r.URL.Query().Get("cover") != "" {
+				return audioCoverArtHandler(w, r, audioSvc, fileCache, file)
+			}
+			return handleAudioPreview(w, r, audioSvc, fileCache, previewSvc, file, previewSize, cfg)
+		case documentExtensions[strings.ToLower(file.Extension)]:
+			return handleDocumentPreview(w, r, docSvc, fileCache, previewSvc, file, previewSize, cfg)
 		default:
 			return http.StatusNotImplemented, fmt.Errorf("can't create preview for %s type", file.Type)
 		}
 	})
 }
 
+// negotiateImageFormat picks the output format for an image preview: an
+// explicit "format" query parameter wins, otherwise the Accept header is
+// checked for AVIF/WebP support, otherwise it falls back to JPEG so clients
+// that advertise neither still get something every browser can render. A
+// format this binary wasn't built to encode (see img.Supports) is treated as
+// if it hadn't been requested at all, so an Accept header alone never turns
+// into a failed request.
+func negotiateImageFormat(r *http.Request) img.Format {
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		if format, ok := img.FormatFromName(raw); ok && img.Supports(format) {
+			return format
+		}
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "image/avif") && img.Supports(img.FormatAvif):
+		return img.FormatAvif
+	case strings.Contains(accept, "image/webp") && img.Supports(img.FormatWebp):
+		return img.FormatWebp
+	default:
+		return img.FormatJpeg
+	}
+}
+
+func imageContentType(format img.Format) string {
+	return "image/" + format.String()
+}
+
 func handleImagePreview(
 	w http.ResponseWriter,
 	r *http.Request,
 	imgSvc ImgService,
 	fileCache FileCache,
+	previewSvc *previews.Service,
 	file *files.FileInfo,
 	previewSize PreviewSize,
+	outFormat img.Format,
 	enableThumbnails, resizePreview bool,
 ) (int, error) {
 	if (previewSize == PreviewSizeBig && !resizePreview) ||
@@ -98,29 +279,25 @@ func handleImagePreview(
 		return errToStatus(err), err
 	}
 
-	cacheKey := previewCacheKey(file, previewSize)
-	resizedImage, ok, err := fileCache.Load(r.Context(), cacheKey)
-	if err != nil {
-		return errToStatus(err), err
-	}
-	if !ok {
-		resizedImage, err = createImagePreview(imgSvc, fileCache, file, previewSize)
-		if err != nil {
-			return errToStatus(err), err
-		}
-	}
-
-	w.Header().Set("Cache-Control", "private")
-	http.ServeContent(w, r, file.Name, file.ModTime, bytes.NewReader(resizedImage))
-
-	return 0, nil
+	cacheKey := previewCacheKey(file, previewSize) + outFormat.String()
+	return serveCachedPreview(w, r, fileCache, cacheKey, file, imageContentType(outFormat), func() ([]byte, error) {
+		return previewSvc.Do(cacheKey, priorityFor(previewSize), func() ([]byte, error) {
+			return createImagePreview(imgSvc, fileCache, file, previewSize, outFormat, cacheKey)
+		})
+	})
 }
 
+// createImagePreview resizes file and stores the result under cacheKey,
+// which the caller has already computed (see previewCacheKey) to check the
+// cache; recomputing it here would mean hashing the source file a second
+// time on every cache miss.
 func createImagePreview(
 	imgSvc ImgService,
 	fileCache FileCache,
 	file *files.FileInfo,
 	previewSize PreviewSize,
+	outFormat img.Format,
+	cacheKey string,
 ) ([]byte, error) {
 	fd, err := file.Fs.Open(file.Path)
 	if err != nil {
@@ -142,10 +319,11 @@ func createImagePreview(
 	case previewSize == PreviewSizeThumb:
 		width = 256
 		height = 256
-		options = append(options, img.WithMode(img.ResizeModeFill), img.WithQuality(img.QualityLow), img.WithFormat(img.FormatJpeg))
+		options = append(options, img.WithMode(img.ResizeModeFill), img.WithQuality(img.QualityLow))
 	default:
 		return nil, img.ErrUnsupportedFormat
 	}
+	options = append(options, img.WithFormat(outFormat))
 
 	buf := &bytes.Buffer{}
 	if err := imgSvc.Resize(context.Background(), fd, width, height, buf, options...); err != nil {
@@ -153,7 +331,6 @@ func createImagePreview(
 	}
 
 	go func() {
-		cacheKey := previewCacheKey(file, previewSize)
 		if err := fileCache.Store(context.Background(), cacheKey, buf.Bytes()); err != nil {
 			fmt.Printf("failed to cache resized image: %v", err)
 		}
@@ -162,103 +339,308 @@ func createImagePreview(
 	return buf.Bytes(), nil
 }
 
+func videoContentType(mode videoPreviewMode) string {
+	if mode == videoModeAnimated {
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
+
 func handleVideoPreview(
 	w http.ResponseWriter,
 	r *http.Request,
+	videoSvc VideoThumbnailer,
 	fileCache FileCache,
+	previewSvc *previews.Service,
 	file *files.FileInfo,
 	previewSize PreviewSize,
+	mode videoPreviewMode,
 ) (int, error) {
-	cacheKey := previewCacheKey(file, previewSize)
-	thumbnail, ok, err := fileCache.Load(r.Context(), cacheKey)
+	cacheKey := previewCacheKey(file, previewSize) + string(mode)
+
+	// The sprite sheet itself is served by this same route/mode; its WebVTT
+	// sidecar is fetched from the same place with an extra "vtt" flag, so
+	// the sheet URL a player needs to embed in the VTT cues is always just
+	// this request's path with the flag stripped.
+	sheetURL := r.URL.Path + "?mode=" + string(mode)
+
+	if mode == videoModeSprite && r.URL.Query().Get("vtt") != "" {
+		vttKey := cacheKey + "-vtt"
+		return serveCachedPreview(w, r, fileCache, vttKey, file, "text/vtt", func() ([]byte, error) {
+			return previewSvc.Do(vttKey, priorityFor(previewSize), func() ([]byte, error) {
+				return createSpriteVTT(videoSvc, fileCache, file, cacheKey, sheetURL)
+			})
+		})
+	}
+
+	return serveCachedPreview(w, r, fileCache, cacheKey, file, videoContentType(mode), func() ([]byte, error) {
+		return previewSvc.Do(cacheKey, priorityFor(previewSize), func() ([]byte, error) {
+			return createVideoThumbnail(videoSvc, fileCache, file, mode, cacheKey, sheetURL)
+		})
+	})
+}
+
+// createVideoThumbnail generates the requested preview via videoSvc and
+// stores it under cacheKey, which the caller has already computed (see
+// previewCacheKey) to check the cache. sheetURL is only used in sprite mode:
+// it's embedded into the sprite's WebVTT sidecar, cached alongside the sheet
+// under a "-vtt" suffixed key, so a player fetching that sidecar (see
+// createSpriteVTT) gets cue references that resolve back to this sheet.
+func createVideoThumbnail(
+	videoSvc VideoThumbnailer,
+	fileCache FileCache,
+	file *files.FileInfo,
+	mode videoPreviewMode,
+	cacheKey string,
+	sheetURL string,
+) ([]byte, error) {
+	absPath := file.RealPath()
+	if _, err := os.Stat(absPath); err != nil {
+		return nil, fmt.Errorf("video preview: resolve source file: %w", err)
+	}
+
+	ctx := context.Background()
+
+	var (
+		thumbnail []byte
+		vtt       []byte
+		err       error
+	)
+
+	switch mode {
+	case videoModeAnimated:
+		thumbnail, err = videoSvc.Animated(ctx, absPath, video.AnimatedOptions{})
+	case videoModeSprite:
+		thumbnail, vtt, err = videoSvc.Sprite(ctx, absPath, video.SpriteOptions{SheetURL: sheetURL})
+	default:
+		thumbnail, err = videoSvc.Frame(ctx, absPath, video.FrameOptions{})
+	}
 	if err != nil {
-		log.Printf("Error loading thumbnail from cache for file %s: %v", file.Path, err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return http.StatusInternalServerError, err
+		return nil, err
 	}
-	if !ok {
-		thumbnail, err = createVideoThumbnail(file, previewSize, fileCache)
-		if err != nil {
-			log.Printf("Error creating video thumbnail for file %s: %v", file.Path, err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return http.StatusInternalServerError, err
+
+	if err := fileCache.Store(ctx, cacheKey, thumbnail); err != nil {
+		log.Printf("Error storing thumbnail in cache: %v", err)
+	}
+	if vtt != nil {
+		if err := fileCache.Store(ctx, cacheKey+"-vtt", vtt); err != nil {
+			log.Printf("Error storing sprite sheet VTT in cache: %v", err)
 		}
 	}
 
-	w.Header().Set("Cache-Control", "private")
-	http.ServeContent(w, r, file.Name, file.ModTime, bytes.NewReader(thumbnail))
-	return http.StatusOK, nil
+	return thumbnail, nil
 }
 
-func createVideoThumbnail(file *files.FileInfo, previewSize PreviewSize, fileCache FileCache) ([]byte, error) {
-	fd, err := file.Fs.Open(file.Path)
-	if err != nil {
-		log.Printf("Error opening file %s: %v", file.Path, err)
+// createSpriteVTT generates the sprite sheet (populating its own cache
+// entry as a side effect, via createVideoThumbnail) and returns just its
+// WebVTT sidecar, for requests made with the "vtt" query flag. sheetCacheKey
+// is the sheet's own cache key (without the "-vtt" suffix), already computed
+// by the caller.
+func createSpriteVTT(
+	videoSvc VideoThumbnailer,
+	fileCache FileCache,
+	file *files.FileInfo,
+	sheetCacheKey string,
+	sheetURL string,
+) ([]byte, error) {
+	if _, err := createVideoThumbnail(videoSvc, fileCache, file, videoModeSprite, sheetCacheKey, sheetURL); err != nil {
 		return nil, err
 	}
-	defer fd.Close()
 
-	tmpFile, err := os.CreateTemp("", "video-thumbnail-*.jpg")
+	vtt, ok, err := fileCache.Load(context.Background(), sheetCacheKey+"-vtt")
 	if err != nil {
-		log.Printf("Error creating temporary file: %v", err)
 		return nil, err
 	}
-	defer os.Remove(tmpFile.Name())
+	if !ok {
+		return nil, fmt.Errorf("video preview: sprite sheet produced no VTT sidecar")
+	}
 
-	log.Printf("Creating thumbnail for file: %s", file.Path)
+	return vtt, nil
+}
 
-	//exePath, err := os.Executable()
+// documentExtensions lists the extensions previewHandler routes to
+// handleDocumentPreview; anything else falls through to the other file.Type
+// branches or the unsupported-type default.
+var documentExtensions = map[string]bool{
+	".pdf":  true,
+	".doc":  true,
+	".docx": true,
+	".ppt":  true,
+	".pptx": true,
+	".xls":  true,
+	".xlsx": true,
+	".odt":  true,
+	".ods":  true,
+	".odp":  true,
+}
+
+func handleDocumentPreview(
+	w http.ResponseWriter,
+	r *http.Request,
+	docSvc DocumentRasterizer,
+	fileCache FileCache,
+	previewSvc *previews.Service,
+	file *files.FileInfo,
+	previewSize PreviewSize,
+	cfg PreviewConfig,
+) (int, error) {
+	if !cfg.EnableDocuments {
+		return rawFileHandler(w, r, file)
+	}
+
+	cacheKey := previewCacheKey(file, previewSize) + "doc"
+	return serveCachedPreview(w, r, fileCache, cacheKey, file, "image/jpeg", func() ([]byte, error) {
+		return previewSvc.Do(cacheKey, priorityFor(previewSize), func() ([]byte, error) {
+			return createDocumentPreview(docSvc, fileCache, file, previewSize, cacheKey)
+		})
+	})
+}
+
+// createDocumentPreview rasterizes file's first page and stores the result
+// under cacheKey, which the caller has already computed (see
+// previewCacheKey) to check the cache.
+func createDocumentPreview(
+	docSvc DocumentRasterizer,
+	fileCache FileCache,
+	file *files.FileInfo,
+	previewSize PreviewSize,
+	cacheKey string,
+) ([]byte, error) {
+	opts := rasterize.Options{Width: 256, Height: 256}
+	if previewSize == PreviewSizeBig {
+		opts = rasterize.Options{Width: 1080, Height: 1080}
+	}
+
+	page, err := docSvc.RenderFirstPage(context.Background(), file.RealPath(), opts)
 	if err != nil {
-		log.Printf("Error getting executable path: %v", err)
 		return nil, err
 	}
-	//exeDir := filepath.Dir(exePath)
 
-	// replace  with /srv/ for docker
-	absPath := filepath.Join("/srv/", file.Path) //filepath.Join(exeDir, file.Path)
+	if err := fileCache.Store(context.Background(), cacheKey, page); err != nil {
+		log.Printf("Error storing document preview in cache: %v", err)
+	}
 
-	log.Printf("File path: %s, Absolute path: %s", file.Path, absPath)
+	return page, nil
+}
 
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		log.Printf("File does not exist: %s", absPath)
-		return nil, fmt.Errorf("file does not exist: %s", absPath)
+func handleAudioPreview(
+	w http.ResponseWriter,
+	r *http.Request,
+	audioSvc AudioPreviewer,
+	fileCache FileCache,
+	previewSvc *previews.Service,
+	file *files.FileInfo,
+	previewSize PreviewSize,
+	cfg PreviewConfig,
+) (int, error) {
+	if !cfg.EnableAudio {
+		return rawFileHandler(w, r, file)
 	}
 
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		if _, err := os.Stat(tmpFile.Name()); err == nil {
-			err = os.Remove(tmpFile.Name())
-			if err != nil {
-				log.Printf("Error deleting existing temporary file (attempt %d/%d): %v", i+1, maxRetries, err)
-				time.Sleep(100 * time.Millisecond) // Wait before retrying
-				continue
-			}
-			break
-		}
+	cacheKey := previewCacheKey(file, previewSize) + "waveform"
+	return serveCachedPreview(w, r, fileCache, cacheKey, file, "image/png", func() ([]byte, error) {
+		return previewSvc.Do(cacheKey, priorityFor(previewSize), func() ([]byte, error) {
+			return createAudioWaveform(audioSvc, fileCache, file, previewSize, cacheKey)
+		})
+	})
+}
+
+// createAudioWaveform renders a waveform image - thumb is a small monochrome
+// strip suited to a file listing, big is a full-width colored waveform
+// suited to a dedicated preview pane - and stores it under cacheKey, which
+// the caller has already computed (see previewCacheKey) to check the cache.
+func createAudioWaveform(
+	audioSvc AudioPreviewer,
+	fileCache FileCache,
+	file *files.FileInfo,
+	previewSize PreviewSize,
+	cacheKey string,
+) ([]byte, error) {
+	opts := audio.WaveformOptions{Width: 256, Height: 64}
+	if previewSize == PreviewSizeBig {
+		opts = audio.WaveformOptions{Width: 1280, Height: 200, Color: "0x4A90D9"}
 	}
 
-	cmd := exec.Command("ffmpeg", "-y", "-i", absPath, "-ss", "00:00:01.000", "-vframes", "1", tmpFile.Name())
-	output, err := cmd.CombinedOutput()
+	waveform, err := audioSvc.Waveform(context.Background(), file.RealPath(), opts)
 	if err != nil {
-		log.Printf("Error running ffmpeg command: %v, output: %s", err, string(output))
 		return nil, err
 	}
 
-	log.Printf("ffmpeg output: %s", string(output))
+	if err := fileCache.Store(context.Background(), cacheKey, waveform); err != nil {
+		log.Printf("Error storing waveform preview in cache: %v", err)
+	}
+
+	return waveform, nil
+}
 
-	thumbnail, err := os.ReadFile(tmpFile.Name())
+// audioCoverArtHandler serves GET .../preview/{size}/{path}?cover=1-style
+// embedded cover art extraction. It's intentionally a thin wrapper around
+// AudioPreviewer.CoverArt rather than routed through the thumb/big
+// machinery: cover art has no size variants, so there's nothing to cache a
+// second copy of per PreviewSize.
+func audioCoverArtHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+	audioSvc AudioPreviewer,
+	fileCache FileCache,
+	file *files.FileInfo,
+) (int, error) {
+	cacheKey := previewCacheKey(file, PreviewSizeThumb) + "cover"
+	art, ok, err := fileCache.Load(r.Context(), cacheKey)
 	if err != nil {
-		log.Printf("Error reading temporary file: %v", err)
-		return nil, err
+		return errToStatus(err), err
 	}
-	cacheKey := previewCacheKey(file, previewSize)
-	if err := fileCache.Store(context.Background(), cacheKey, thumbnail); err != nil {
-		log.Printf("Error storing thumbnail in cache: %v", err)
+	if !ok {
+		art, ok, err = audioSvc.CoverArt(r.Context(), file.RealPath())
+		if err != nil {
+			return errToStatus(err), err
+		}
+		if !ok {
+			return http.StatusNotFound, nil
+		}
+		if err := fileCache.Store(r.Context(), cacheKey, art); err != nil {
+			log.Printf("Error storing cover art in cache: %v", err)
+		}
 	}
 
-	return thumbnail, nil
+	w.Header().Set("Cache-Control", "private")
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeContent(w, r, file.Name, file.ModTime, bytes.NewReader(art))
+
+	return http.StatusOK, nil
 }
 
+// previewCacheKey derives a cache key from the file's content rather than
+// its ModTime, so touching a file without changing its bytes (a common
+// side-effect of copies, backups and some sync tools) doesn't invalidate an
+// otherwise-valid cached preview.
 func previewCacheKey(f *files.FileInfo, previewSize PreviewSize) string {
-	return fmt.Sprintf("%x%x%x", f.RealPath(), f.ModTime.Unix(), previewSize)
+	hash, err := contentHash(f)
+	if err != nil {
+		// A hashing failure only costs a cache miss, not a broken request.
+		hash = fmt.Sprintf("fallback-%x-%x", f.RealPath(), f.ModTime.Unix())
+	}
+	return fmt.Sprintf("%s%x", hash, previewSize)
+}
+
+// contentHash returns a content-derived identifier for f. Files larger than
+// largeFileHashThreshold use a size+mtime fast path instead of streaming
+// the full content through the hasher.
+func contentHash(f *files.FileInfo) (string, error) {
+	if f.Size > largeFileHashThreshold {
+		return fmt.Sprintf("fast-%x-%x-%x", f.RealPath(), f.Size, f.ModTime.Unix()), nil
+	}
+
+	fd, err := f.Fs.Open(f.Path)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+
+	h := xxhash.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum64()), nil
 }
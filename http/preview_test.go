@@ -0,0 +1,28 @@
+package http
+
+import "testing"
+
+func TestParseVideoPreviewMode(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    videoPreviewMode
+		wantErr bool
+	}{
+		{"", videoModeFrame, false},
+		{"frame", videoModeFrame, false},
+		{"animated", videoModeAnimated, false},
+		{"sprite", videoModeSprite, false},
+		{"bogus", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseVideoPreviewMode(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseVideoPreviewMode(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("parseVideoPreviewMode(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
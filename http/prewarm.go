@@ -0,0 +1,188 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/img"
+	"github.com/filebrowser/filebrowser/v2/previews"
+)
+
+type prewarmRequest struct {
+	Path string `json:"path"`
+}
+
+type prewarmResponse struct {
+	JobID string `json:"jobId"`
+}
+
+// prewarmHandler enqueues background thumbnailing, at bulk priority, for
+// every previewable file under req.Path (recursive, respecting the
+// requesting user's permissions), returning a job ID the UI polls via
+// prewarmStatusHandler. Useful for pre-warming the preview cache on large
+// libraries before anyone browses them.
+func prewarmHandler(
+	imgSvc ImgService,
+	videoSvc VideoThumbnailer,
+	docSvc DocumentRasterizer,
+	audioSvc AudioPreviewer,
+	fileCache FileCache,
+	previewSvc *previews.Service,
+	jobs *previews.JobManager,
+	cfg PreviewConfig,
+) handleFunc {
+	return withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		var req prewarmRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		root := "/" + strings.TrimPrefix(req.Path, "/")
+
+		job := jobs.New()
+		go prewarmDirectory(imgSvc, videoSvc, docSvc, audioSvc, fileCache, previewSvc, d, job, root, cfg)
+
+		return renderJSON(w, r, &prewarmResponse{JobID: job.ID})
+	})
+}
+
+// prewarmDirectory walks root and generates a thumb preview for every file
+// the requesting user can see, recording progress on job as it goes. It
+// covers the same kinds previewHandler does (image, video, document, audio);
+// anything else - and anything whose kind is disabled in cfg - is logged and
+// left out of job's totals rather than silently counted as done.
+func prewarmDirectory(
+	imgSvc ImgService,
+	videoSvc VideoThumbnailer,
+	docSvc DocumentRasterizer,
+	audioSvc AudioPreviewer,
+	fileCache FileCache,
+	previewSvc *previews.Service,
+	d *data,
+	job *previews.Job,
+	root string,
+	cfg PreviewConfig,
+) {
+	walkErr := afero.Walk(d.user.Fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Check(path) {
+			// A denied directory must stop the walk from descending into
+			// its children outright; returning nil here would still let
+			// Walk visit (and expose files under) a directory the user
+			// isn't permitted to see, relying on each descendant to
+			// independently fail the same check.
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := files.NewFileInfo(&files.FileOptions{
+			Fs:      d.user.Fs,
+			Path:    path,
+			Modify:  d.user.Perm.Modify,
+			Expand:  true,
+			Checker: d,
+		})
+		if err != nil {
+			job.AddTotal(1)
+			job.MarkDone(err)
+			return nil
+		}
+
+		switch {
+		case file.Type == "image":
+			if !cfg.EnableThumbnails {
+				log.Printf("prewarm: skipping %s: image thumbnails disabled", path)
+				return nil
+			}
+			job.AddTotal(1)
+			cacheKey := previewCacheKey(file, PreviewSizeThumb) + img.FormatJpeg.String()
+			_, err = previewSvc.Do(
+				cacheKey,
+				previews.PriorityBulk,
+				func() ([]byte, error) {
+					return createImagePreview(imgSvc, fileCache, file, PreviewSizeThumb, img.FormatJpeg, cacheKey)
+				},
+			)
+		case file.Type == "video":
+			job.AddTotal(1)
+			cacheKey := previewCacheKey(file, PreviewSizeThumb) + string(videoModeFrame)
+			_, err = previewSvc.Do(
+				cacheKey,
+				previews.PriorityBulk,
+				func() ([]byte, error) {
+					return createVideoThumbnail(videoSvc, fileCache, file, videoModeFrame, cacheKey, "")
+				},
+			)
+		case file.Type == "audio":
+			if !cfg.EnableAudio {
+				log.Printf("prewarm: skipping %s: audio previews disabled", path)
+				return nil
+			}
+			job.AddTotal(1)
+			cacheKey := previewCacheKey(file, PreviewSizeThumb) + "waveform"
+			_, err = previewSvc.Do(
+				cacheKey,
+				previews.PriorityBulk,
+				func() ([]byte, error) {
+					return createAudioWaveform(audioSvc, fileCache, file, PreviewSizeThumb, cacheKey)
+				},
+			)
+		case documentExtensions[strings.ToLower(file.Extension)]:
+			if !cfg.EnableDocuments {
+				log.Printf("prewarm: skipping %s: document previews disabled", path)
+				return nil
+			}
+			job.AddTotal(1)
+			cacheKey := previewCacheKey(file, PreviewSizeThumb) + "doc"
+			_, err = previewSvc.Do(
+				cacheKey,
+				previews.PriorityBulk,
+				func() ([]byte, error) {
+					return createDocumentPreview(docSvc, fileCache, file, PreviewSizeThumb, cacheKey)
+				},
+			)
+		default:
+			log.Printf("prewarm: skipping %s: no preview kind for this file", path)
+			return nil
+		}
+		job.MarkDone(err)
+
+		return nil
+	})
+
+	job.Finish(walkErr)
+}
+
+// prewarmStatusHandler serves GET /api/previews/jobs/{id} with progress for
+// a job started by prewarmHandler.
+func prewarmStatusHandler(jobs *previews.JobManager) handleFunc {
+	return withAdmin(func(w http.ResponseWriter, r *http.Request, _ *data) (int, error) {
+		job, ok := jobs.Get(mux.Vars(r)["id"])
+		if !ok {
+			return http.StatusNotFound, nil
+		}
+
+		completed, failed, total, status := job.Progress()
+		return renderJSON(w, r, map[string]any{
+			"id":        job.ID,
+			"status":    status,
+			"completed": completed,
+			"failed":    failed,
+			"total":     total,
+		})
+	})
+}
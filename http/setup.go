@@ -0,0 +1,121 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/filebrowser/filebrowser/v2/audio"
+	"github.com/filebrowser/filebrowser/v2/diskcache"
+	"github.com/filebrowser/filebrowser/v2/img"
+	"github.com/filebrowser/filebrowser/v2/previews"
+	"github.com/filebrowser/filebrowser/v2/rasterize"
+	"github.com/filebrowser/filebrowser/v2/video"
+)
+
+// PreviewEngineConfig configures the preview subsystem: external tool
+// binaries, the on-disk cache's bounds, and which preview kinds are enabled.
+// These are meant to be sourced from CLI flags and the settings JSON.
+type PreviewEngineConfig struct {
+	FFmpegBin      string
+	PdftoppmBin    string
+	LibreOfficeBin string
+
+	CacheDir     string
+	CacheMaxSize int64
+	CacheMaxAge  time.Duration
+	Workers      int
+
+	EnableThumbnails bool
+	ResizePreview    bool
+	EnableVideo      bool
+	EnableDocuments  bool
+	EnableAudio      bool
+}
+
+// previewServices bundles the concrete implementations the preview and
+// cache-management routes depend on, so they can be built once at startup
+// and threaded through RegisterPreviewRoutes.
+type previewServices struct {
+	img      ImgService
+	video    VideoThumbnailer
+	document DocumentRasterizer
+	audio    AudioPreviewer
+	cache    FileCache
+	workers  *previews.Service
+	jobs     *previews.JobManager
+	config   PreviewConfig
+}
+
+// newPreviewServices builds the default ffmpeg/pdftoppm/libreoffice-backed
+// previewServices from cfg. Any feature left disabled in cfg gets its Noop
+// implementation instead, so previewHandler and prewarmDirectory don't need
+// to special-case a missing dependency.
+func newPreviewServices(cfg PreviewEngineConfig) (*previewServices, error) {
+	cache, err := diskcache.New(cfg.CacheDir, diskcache.Options{
+		MaxSize: cfg.CacheMaxSize,
+		MaxAge:  cfg.CacheMaxAge,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	videoSvc := VideoThumbnailer(video.NoopThumbnailer{})
+	if cfg.EnableVideo {
+		videoSvc = video.NewFFmpegThumbnailer(cfg.FFmpegBin)
+	}
+
+	docSvc := DocumentRasterizer(rasterize.NoopRasterizer{})
+	if cfg.EnableDocuments {
+		docSvc = rasterize.NewComposite(
+			rasterize.NewPdftoppmRasterizer(cfg.PdftoppmBin),
+			rasterize.NewLibreOfficeRasterizer(cfg.LibreOfficeBin),
+		)
+	}
+
+	audioSvc := AudioPreviewer(audio.NoopPreviewer{})
+	if cfg.EnableAudio {
+		audioSvc = audio.NewFFmpegPreviewer(cfg.FFmpegBin)
+	}
+
+	return &previewServices{
+		img:      img.NewService(),
+		video:    videoSvc,
+		document: docSvc,
+		audio:    audioSvc,
+		cache:    cache,
+		workers:  previews.NewService(cfg.Workers),
+		jobs:     previews.NewJobManager(),
+		config: PreviewConfig{
+			EnableThumbnails: cfg.EnableThumbnails,
+			ResizePreview:    cfg.ResizePreview,
+			EnableDocuments:  cfg.EnableDocuments,
+			EnableAudio:      cfg.EnableAudio,
+		},
+	}, nil
+}
+
+// RegisterPreviewRoutes wires previewHandler and its admin-only siblings
+// (cache stats, pre-warm) into r, following the same handle()-wrapped
+// handleFunc convention the rest of this package's routes use. Call it from
+// the server's route setup alongside the other RegisterXRoutes calls.
+func RegisterPreviewRoutes(r *mux.Router, cfg PreviewEngineConfig) error {
+	svc, err := newPreviewServices(cfg)
+	if err != nil {
+		return err
+	}
+
+	r.Handle("/api/preview/{size}/{path:.*}", handle(previewHandler(
+		svc.img, svc.video, svc.document, svc.audio, svc.cache, svc.workers, svc.config,
+	))).Methods("GET")
+
+	r.Handle("/api/cache/stats", handle(cacheStatsHandler(svc.cache))).Methods("GET")
+
+	r.Handle("/api/previews/prewarm", handle(prewarmHandler(
+		svc.img, svc.video, svc.document, svc.audio, svc.cache, svc.workers, svc.jobs, svc.config,
+	))).Methods("POST")
+
+	r.Handle("/api/previews/jobs/{id}", handle(prewarmStatusHandler(svc.jobs))).Methods("GET")
+
+	return nil
+}
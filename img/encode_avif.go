@@ -0,0 +1,19 @@
+//go:build !avif
+
+package img
+
+import (
+	"image"
+	"io"
+)
+
+// avifSupported reports that this build wasn't compiled with the "avif" tag,
+// so callers should negotiate a different output format instead of calling
+// encodeAvif and getting ErrUnsupportedFormat back.
+const avifSupported = false
+
+// encodeAvif is the default build: this binary wasn't compiled with the
+// "avif" tag (which links libavif via CGO), so AVIF output isn't available.
+func encodeAvif(io.Writer, image.Image, Quality) error {
+	return ErrUnsupportedFormat
+}
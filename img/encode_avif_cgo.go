@@ -0,0 +1,31 @@
+//go:build avif
+
+package img
+
+import (
+	"image"
+	"io"
+
+	"github.com/gen2brain/avif"
+)
+
+// avifSupported reports that this build was compiled with the "avif" tag,
+// so encodeAvif is backed by a real encoder.
+const avifSupported = true
+
+// encodeAvif encodes im as AVIF via libavif (CGO). Build with `-tags avif`
+// and a libavif toolchain available to enable it.
+func encodeAvif(out io.Writer, im image.Image, quality Quality) error {
+	return avif.Encode(out, im, avif.Options{Quality: avifQuality(quality)})
+}
+
+func avifQuality(q Quality) int {
+	switch q {
+	case QualityLow:
+		return 40
+	case QualityHigh:
+		return 80
+	default:
+		return 60
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !webp
+
+package img
+
+import (
+	"image"
+	"io"
+)
+
+// webpSupported reports that this build wasn't compiled with the "webp" tag,
+// so callers should negotiate a different output format instead of calling
+// encodeWebp and getting ErrUnsupportedFormat back.
+const webpSupported = false
+
+// encodeWebp is the default build: this binary wasn't compiled with the
+// "webp" tag (which links libwebp via CGO), so WebP output isn't available.
+func encodeWebp(io.Writer, image.Image, Quality) error {
+	return ErrUnsupportedFormat
+}
@@ -0,0 +1,31 @@
+//go:build webp
+
+package img
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// webpSupported reports that this build was compiled with the "webp" tag,
+// so encodeWebp is backed by a real encoder.
+const webpSupported = true
+
+// encodeWebp encodes im as lossy WebP via libwebp (CGO). Build with
+// `-tags webp` and a libwebp-dev toolchain available to enable it.
+func encodeWebp(out io.Writer, im image.Image, quality Quality) error {
+	return webp.Encode(out, im, &webp.Options{Lossless: false, Quality: float32(webpQuality(quality))})
+}
+
+func webpQuality(q Quality) int {
+	switch q {
+	case QualityLow:
+		return 60
+	case QualityHigh:
+		return 92
+	default:
+		return 80
+	}
+}
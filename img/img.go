@@ -0,0 +1,230 @@
+// Package img resizes and re-encodes images for the preview system.
+package img
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // registers WebP decoding with image.Decode
+)
+
+// ErrUnsupportedFormat is returned by FormatFromExtension for extensions
+// img doesn't know how to decode, and by Resize when asked to encode to a
+// Format this build wasn't compiled to support (see the "webp"/"avif"
+// build tags).
+var ErrUnsupportedFormat = errors.New("img: unsupported format")
+
+// Format identifies an image encoding, for both input detection and output
+// negotiation.
+type Format int
+
+const (
+	FormatJpeg Format = iota
+	FormatPng
+	FormatGif
+	FormatWebp
+	FormatAvif
+)
+
+// String returns the lowercase name used in MIME types and the "format"
+// query parameter, e.g. "webp".
+func (f Format) String() string {
+	switch f {
+	case FormatJpeg:
+		return "jpeg"
+	case FormatPng:
+		return "png"
+	case FormatGif:
+		return "gif"
+	case FormatWebp:
+		return "webp"
+	case FormatAvif:
+		return "avif"
+	default:
+		return "unknown"
+	}
+}
+
+// FormatFromExtension maps a file extension (as returned by
+// files.FileInfo.Extension, including the leading dot) to a Format.
+func FormatFromExtension(ext string) (Format, error) {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return FormatJpeg, nil
+	case ".png":
+		return FormatPng, nil
+	case ".gif":
+		return FormatGif, nil
+	case ".webp":
+		return FormatWebp, nil
+	case ".avif":
+		return FormatAvif, nil
+	default:
+		return 0, ErrUnsupportedFormat
+	}
+}
+
+// FormatFromName parses a Format from its String() representation, as used
+// by the "format" query parameter and by image.Decode's format names.
+func FormatFromName(name string) (Format, bool) {
+	switch strings.ToLower(name) {
+	case "jpeg", "jpg":
+		return FormatJpeg, true
+	case "png":
+		return FormatPng, true
+	case "gif":
+		return FormatGif, true
+	case "webp":
+		return FormatWebp, true
+	case "avif":
+		return FormatAvif, true
+	default:
+		return 0, false
+	}
+}
+
+// Supports reports whether this build can encode to format. WebP and AVIF
+// are only available when built with the "webp"/"avif" tags (and their CGO
+// toolchains); callers negotiating an output format should check this
+// before picking one of those over FormatJpeg.
+func Supports(format Format) bool {
+	switch format {
+	case FormatWebp:
+		return webpSupported
+	case FormatAvif:
+		return avifSupported
+	default:
+		return true
+	}
+}
+
+// Quality is a size/fidelity preset for lossy encoders.
+type Quality int
+
+const (
+	QualityLow Quality = iota
+	QualityMedium
+	QualityHigh
+)
+
+// ResizeMode controls how the source aspect ratio is reconciled with the
+// requested bounding box.
+type ResizeMode int
+
+const (
+	// ResizeModeFit scales the image down to fit entirely within the box,
+	// preserving aspect ratio.
+	ResizeModeFit ResizeMode = iota
+	// ResizeModeFill scales and crops to fill the box exactly.
+	ResizeModeFill
+)
+
+type options struct {
+	mode    ResizeMode
+	quality Quality
+	format  *Format
+}
+
+// Option configures a Resize call.
+type Option func(*options)
+
+// WithMode sets the resize mode. The default is ResizeModeFit.
+func WithMode(mode ResizeMode) Option {
+	return func(o *options) { o.mode = mode }
+}
+
+// WithQuality sets the lossy encoding quality preset. The default is
+// QualityMedium.
+func WithQuality(q Quality) Option {
+	return func(o *options) { o.quality = q }
+}
+
+// WithFormat overrides the output format. Without it, Resize encodes using
+// the source image's decoded format.
+func WithFormat(f Format) Option {
+	return func(o *options) { o.format = &f }
+}
+
+// Service resizes images using github.com/disintegration/imaging and
+// encodes the result to one of the supported Formats.
+type Service struct{}
+
+// NewService returns a ready-to-use image Service.
+func NewService() *Service {
+	return &Service{}
+}
+
+// FormatFromExtension implements http.ImgService.
+func (*Service) FormatFromExtension(ext string) (Format, error) {
+	return FormatFromExtension(ext)
+}
+
+// Resize decodes in, scales it to fit or fill width x height per the given
+// options, and writes the encoded result to out.
+func (*Service) Resize(_ context.Context, in io.Reader, width, height int, out io.Writer, opts ...Option) error {
+	o := options{mode: ResizeModeFit, quality: QualityMedium}
+	for _, apply := range opts {
+		apply(&o)
+	}
+
+	src, srcFormat, err := image.Decode(in)
+	if err != nil {
+		return err
+	}
+
+	var resized image.Image
+	switch o.mode {
+	case ResizeModeFill:
+		resized = imaging.Fill(src, width, height, imaging.Center, imaging.Lanczos)
+	default:
+		resized = imaging.Fit(src, width, height, imaging.Lanczos)
+	}
+
+	format := formatFromDecoded(srcFormat)
+	if o.format != nil {
+		format = *o.format
+	}
+
+	return encode(out, resized, format, o.quality)
+}
+
+func formatFromDecoded(name string) Format {
+	f, ok := FormatFromName(name)
+	if !ok {
+		return FormatJpeg
+	}
+	return f
+}
+
+func jpegQuality(q Quality) int {
+	switch q {
+	case QualityLow:
+		return 60
+	case QualityHigh:
+		return 92
+	default:
+		return 80
+	}
+}
+
+func encode(out io.Writer, im image.Image, format Format, quality Quality) error {
+	switch format {
+	case FormatPng:
+		return png.Encode(out, im)
+	case FormatGif:
+		return gif.Encode(out, im, nil)
+	case FormatWebp:
+		return encodeWebp(out, im, quality)
+	case FormatAvif:
+		return encodeAvif(out, im, quality)
+	default:
+		return jpeg.Encode(out, im, &jpeg.Options{Quality: jpegQuality(quality)})
+	}
+}
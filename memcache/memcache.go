@@ -0,0 +1,67 @@
+// Package memcache is a process-memory FileCache implementation, useful for
+// tests and for deployments that would rather trade persistence for not
+// touching disk at all.
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// Cache is an unbounded in-memory FileCache. Unlike diskcache.Cache it
+// enforces no size or age limit; callers that need bounds should use
+// diskcache instead.
+type Cache struct {
+	mu    sync.RWMutex
+	items map[string][]byte
+}
+
+// New returns an empty Cache.
+func New() *Cache {
+	return &Cache{items: map[string][]byte{}}
+}
+
+func (c *Cache) Store(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+	return nil
+}
+
+func (c *Cache) Load(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.items[key]
+	return value, ok, nil
+}
+
+func (c *Cache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+// readSeekCloser adapts a *bytes.Reader, which has no Close method, to
+// io.ReadSeekCloser.
+type readSeekCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekCloser) Close() error { return nil }
+
+// OpenReader wraps the cached value in a *bytes.Reader. It returns an error
+// satisfying errors.Is(err, fs.ErrNotExist) on a cache miss.
+func (c *Cache) OpenReader(_ context.Context, key string) (io.ReadSeekCloser, int64, error) {
+	c.mu.RLock()
+	value, ok := c.items[key]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, 0, fs.ErrNotExist
+	}
+
+	return readSeekCloser{bytes.NewReader(value)}, int64(len(value)), nil
+}
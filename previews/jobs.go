@@ -0,0 +1,93 @@
+package previews
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// JobStatus is the lifecycle state of a pre-warm Job.
+type JobStatus string
+
+const (
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	JobStatusError   JobStatus = "error"
+)
+
+// Job tracks progress of a single recursive pre-warm request, polled by the
+// UI via GET /api/previews/jobs/{id}.
+type Job struct {
+	ID string
+
+	total     int64
+	completed int64
+	failed    int64
+
+	mu     sync.Mutex
+	status JobStatus
+	errMsg string
+}
+
+// Progress reports how many of Total files have been processed so far.
+func (j *Job) Progress() (completed, failed, total int64, status JobStatus) {
+	j.mu.Lock()
+	status = j.status
+	j.mu.Unlock()
+	return atomic.LoadInt64(&j.completed), atomic.LoadInt64(&j.failed), atomic.LoadInt64(&j.total), status
+}
+
+// AddTotal increments the number of files discovered to process.
+func (j *Job) AddTotal(n int64) { atomic.AddInt64(&j.total, n) }
+
+// MarkDone records the outcome of processing a single file.
+func (j *Job) MarkDone(err error) {
+	if err != nil {
+		atomic.AddInt64(&j.failed, 1)
+		return
+	}
+	atomic.AddInt64(&j.completed, 1)
+}
+
+// Finish transitions the job to its terminal status.
+func (j *Job) Finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.status = JobStatusError
+		j.errMsg = err.Error()
+		return
+	}
+	j.status = JobStatusDone
+}
+
+// JobManager hands out and tracks Jobs for in-flight pre-warm requests.
+type JobManager struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+	seq  uint64
+}
+
+// NewJobManager returns an empty JobManager.
+func NewJobManager() *JobManager {
+	return &JobManager{jobs: map[string]*Job{}}
+}
+
+// New registers and returns a fresh Job in the running state.
+func (m *JobManager) New() *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	j := &Job{ID: fmt.Sprintf("prewarm-%d", m.seq), status: JobStatusRunning}
+	m.jobs[j.ID] = j
+	return j
+}
+
+// Get looks up a Job by ID.
+func (m *JobManager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	return j, ok
+}
@@ -0,0 +1,140 @@
+// Package previews runs preview generation off the request goroutine on a
+// bounded worker pool, coalescing concurrent requests for the same cache key
+// and letting interactive ("thumb") work preempt bulk ("big") work queued
+// ahead of it.
+package previews
+
+import (
+	"container/heap"
+	"errors"
+	"runtime"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Priority orders queued work. Higher values run first.
+type Priority int
+
+const (
+	// PriorityBulk is for background/pre-warm generation that can tolerate
+	// waiting behind interactive requests.
+	PriorityBulk Priority = iota
+	// PriorityInteractive is for previews a user is actively waiting on.
+	PriorityInteractive
+)
+
+type job struct {
+	priority Priority
+	seq      int64
+	fn       func() ([]byte, error)
+	done     chan struct{}
+	data     []byte
+	err      error
+}
+
+// priorityQueue orders jobs by Priority, then FIFO within a tier.
+type priorityQueue []*job
+
+func (q priorityQueue) Len() int { return len(q) }
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x any)   { *q = append(*q, x.(*job)) }
+func (q *priorityQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// Service schedules preview generation across a fixed pool of workers.
+type Service struct {
+	group singleflight.Group
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   priorityQueue
+	nextSeq int64
+	closed  bool
+}
+
+// NewService starts a pool of workers pulling from a shared priority queue.
+// A workers value <= 0 defaults to runtime.NumCPU.
+func NewService(workers int) *Service {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	s := &Service{}
+	s.cond = sync.NewCond(&s.mu)
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+
+	return s
+}
+
+// Do runs fn on the worker pool at the given priority, coalescing concurrent
+// calls that share key into a single execution whose result is shared by
+// all callers.
+func (s *Service) Do(key string, priority Priority, fn func() ([]byte, error)) ([]byte, error) {
+	v, err, _ := s.group.Do(key, func() (interface{}, error) {
+		return s.submit(priority, fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+func (s *Service) submit(priority Priority, fn func() ([]byte, error)) ([]byte, error) {
+	j := &job{priority: priority, fn: fn, done: make(chan struct{})}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, errors.New("previews: service closed")
+	}
+	j.seq = s.nextSeq
+	s.nextSeq++
+	heap.Push(&s.queue, j)
+	s.cond.Signal()
+	s.mu.Unlock()
+
+	<-j.done
+	return j.data, j.err
+}
+
+func (s *Service) worker() {
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 && s.closed {
+			s.mu.Unlock()
+			return
+		}
+		j, _ := heap.Pop(&s.queue).(*job)
+		s.mu.Unlock()
+
+		j.data, j.err = j.fn()
+		close(j.done)
+	}
+}
+
+// Close stops accepting new work: later Do calls return an error. Workers
+// exit once queued jobs drain; Close does not cancel jobs already running or
+// queued.
+func (s *Service) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
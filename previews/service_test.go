@@ -0,0 +1,156 @@
+package previews
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitQueueLen polls s's queue until it reaches at least n entries, so tests
+// can submit work that's guaranteed to be queued (not yet picked up by a
+// worker) before asserting on ordering.
+func waitQueueLen(t *testing.T, s *Service, n int) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		s.mu.Lock()
+		l := len(s.queue)
+		s.mu.Unlock()
+		if l >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("queue did not reach length %d in time", n)
+}
+
+func TestInteractivePreemptsQueuedBulk(t *testing.T) {
+	s := NewService(1)
+	defer s.Close()
+
+	// Occupy the single worker so the jobs submitted below are forced to
+	// queue up instead of running immediately.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go s.Do("blocker", PriorityInteractive, func() ([]byte, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	<-started
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil, nil
+		}
+	}
+
+	bulkDone := make(chan struct{})
+	go func() {
+		_, _ = s.Do("bulk", PriorityBulk, record("bulk"))
+		close(bulkDone)
+	}()
+	waitQueueLen(t, s, 1)
+
+	interactiveDone := make(chan struct{})
+	go func() {
+		_, _ = s.Do("interactive", PriorityInteractive, record("interactive"))
+		close(interactiveDone)
+	}()
+	waitQueueLen(t, s, 2)
+
+	close(release)
+	<-bulkDone
+	<-interactiveDone
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != "interactive" || order[1] != "bulk" {
+		t.Errorf("execution order = %v, want [interactive bulk]: a later-queued interactive job should preempt an earlier-queued bulk one", order)
+	}
+}
+
+func TestDoCoalescesConcurrentCallsWithSameKey(t *testing.T) {
+	s := NewService(4)
+	defer s.Close()
+
+	var calls int32
+	start := make(chan struct{})
+	fn := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		<-start
+		return []byte("result"), nil
+	}
+
+	const n = 5
+	var wg sync.WaitGroup
+	results := make([][]byte, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = s.Do("same-key", PriorityBulk, fn)
+		}(i)
+	}
+
+	// Give every goroutine a chance to join the singleflight call before fn
+	// is allowed to return, so a bug that ran fn per-caller would show up as
+	// calls > 1 instead of racing a still-pending call.
+	time.Sleep(50 * time.Millisecond)
+	close(start)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fn ran %d times for %d concurrent Do calls sharing a key, want 1", got, n)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Errorf("Do[%d] error = %v", i, errs[i])
+		}
+		if string(results[i]) != "result" {
+			t.Errorf("Do[%d] = %q, want %q", i, results[i], "result")
+		}
+	}
+}
+
+func TestCloseDrainsQueuedJobs(t *testing.T) {
+	s := NewService(1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go s.Do("blocker", PriorityInteractive, func() ([]byte, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	<-started
+
+	var executed int32
+	queuedDone := make(chan struct{})
+	go func() {
+		_, _ = s.Do("queued", PriorityBulk, func() ([]byte, error) {
+			atomic.AddInt32(&executed, 1)
+			return []byte("ok"), nil
+		})
+		close(queuedDone)
+	}()
+	waitQueueLen(t, s, 1)
+
+	s.Close()
+	close(release)
+
+	select {
+	case <-queuedDone:
+	case <-time.After(time.Second):
+		t.Fatal("job queued before Close did not run, want Close to drain it")
+	}
+	if atomic.LoadInt32(&executed) != 1 {
+		t.Errorf("queued job ran %d times, want 1", executed)
+	}
+}
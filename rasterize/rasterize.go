@@ -0,0 +1,162 @@
+// Package rasterize renders the first page of a document (PDF or common
+// Office format) to a raster image, for use as a preview thumbnail.
+package rasterize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for extensions no configured Rasterizer
+// knows how to handle.
+var ErrUnsupportedFormat = errors.New("rasterize: unsupported document format")
+
+// Options controls first-page rendering. A zero Width or Height lets the
+// underlying tool pick its own default resolution.
+type Options struct {
+	Width, Height int
+}
+
+// Rasterizer renders the first page of a document at path to a raster
+// image (JPEG).
+type Rasterizer interface {
+	RenderFirstPage(ctx context.Context, path string, opts Options) ([]byte, error)
+}
+
+// PdftoppmRasterizer renders PDFs via poppler's pdftoppm.
+type PdftoppmRasterizer struct {
+	// Bin is the pdftoppm executable to invoke. Defaults to "pdftoppm".
+	Bin string
+}
+
+// NewPdftoppmRasterizer returns a Rasterizer backed by the given pdftoppm
+// binary. An empty bin defaults to "pdftoppm" on PATH.
+func NewPdftoppmRasterizer(bin string) *PdftoppmRasterizer {
+	return &PdftoppmRasterizer{Bin: bin}
+}
+
+func (t *PdftoppmRasterizer) bin() string {
+	if t.Bin == "" {
+		return "pdftoppm"
+	}
+	return t.Bin
+}
+
+func (t *PdftoppmRasterizer) RenderFirstPage(ctx context.Context, path string, opts Options) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "pdf-preview-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	outPrefix := filepath.Join(dir, "page")
+	args := []string{"-jpeg", "-f", "1", "-l", "1", "-singlefile"}
+	if opts.Width > 0 {
+		args = append(args, "-scale-to-x", strconv.Itoa(opts.Width))
+	}
+	if opts.Height > 0 {
+		args = append(args, "-scale-to-y", strconv.Itoa(opts.Height))
+	}
+	args = append(args, path, outPrefix)
+
+	cmd := exec.CommandContext(ctx, t.bin(), args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %w: %s", err, output)
+	}
+
+	return os.ReadFile(outPrefix + ".jpg")
+}
+
+// LibreOfficeRasterizer converts common Office formats (docx, xlsx, pptx,
+// odt, ...) to PDF via `libreoffice --headless`, then rasterizes the first
+// page of that PDF with PDF.
+type LibreOfficeRasterizer struct {
+	// Bin is the libreoffice executable to invoke. Defaults to "libreoffice".
+	Bin string
+	// PDF rasterizes the PDF libreoffice produces. Defaults to a
+	// PdftoppmRasterizer.
+	PDF Rasterizer
+}
+
+// NewLibreOfficeRasterizer returns a Rasterizer backed by the given
+// libreoffice binary. An empty bin defaults to "libreoffice" on PATH.
+func NewLibreOfficeRasterizer(bin string) *LibreOfficeRasterizer {
+	return &LibreOfficeRasterizer{Bin: bin, PDF: NewPdftoppmRasterizer("")}
+}
+
+func (t *LibreOfficeRasterizer) bin() string {
+	if t.Bin == "" {
+		return "libreoffice"
+	}
+	return t.Bin
+}
+
+func (t *LibreOfficeRasterizer) RenderFirstPage(ctx context.Context, path string, opts Options) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "office-preview-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.CommandContext(ctx, t.bin(), "--headless", "--convert-to", "pdf", "--outdir", dir, path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("libreoffice: %w: %s", err, output)
+	}
+
+	pdf := t.PDF
+	if pdf == nil {
+		pdf = NewPdftoppmRasterizer("")
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return pdf.RenderFirstPage(ctx, filepath.Join(dir, base+".pdf"), opts)
+}
+
+// officeExtensions lists the document extensions routed to the Office
+// rasterizer by Composite; anything else falls through to the PDF one.
+var officeExtensions = map[string]bool{
+	".doc": true, ".docx": true,
+	".ppt": true, ".pptx": true,
+	".xls": true, ".xlsx": true,
+	".odt": true, ".ods": true, ".odp": true,
+}
+
+// Composite dispatches to a PDF or Office Rasterizer based on the file
+// extension, so callers can register one Rasterizer for the whole document
+// preview feature.
+type Composite struct {
+	PDF    Rasterizer
+	Office Rasterizer
+}
+
+// NewComposite returns a Rasterizer that routes PDFs to pdf and Office
+// documents to office.
+func NewComposite(pdf, office Rasterizer) *Composite {
+	return &Composite{PDF: pdf, Office: office}
+}
+
+func (c *Composite) RenderFirstPage(ctx context.Context, path string, opts Options) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".pdf":
+		return c.PDF.RenderFirstPage(ctx, path, opts)
+	case officeExtensions[ext]:
+		return c.Office.RenderFirstPage(ctx, path, opts)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// NoopRasterizer never generates previews; it exists so administrators can
+// disable document thumbnailing entirely without changing call sites.
+type NoopRasterizer struct{}
+
+func (NoopRasterizer) RenderFirstPage(context.Context, string, Options) ([]byte, error) {
+	return nil, ErrUnsupportedFormat
+}
@@ -0,0 +1,278 @@
+// Package video extracts preview images from video files on disk.
+//
+// Implementations shell out to an external decoder (ffmpeg by default) since
+// there is no pure-Go video decoder suitable for arbitrary container/codec
+// combinations. Callers resolve a real filesystem path (see
+// files.FileInfo.RealPath) before calling any of these methods.
+package video
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDisabled is returned by NoopThumbnailer to signal that video
+// thumbnailing has been turned off by configuration.
+var ErrDisabled = errors.New("video thumbnailing is disabled")
+
+// FrameOptions controls single-frame extraction.
+type FrameOptions struct {
+	// Offset is how far into the video to seek before grabbing the frame.
+	// Zero defaults to one second.
+	Offset time.Duration
+}
+
+// AnimatedOptions controls looping animated-preview generation.
+type AnimatedOptions struct {
+	// Frames is how many sampled frames make up the loop. Zero defaults to 10.
+	Frames int
+	// Width and Height bound the output; zero defaults to 320x200.
+	Width, Height int
+}
+
+// SpriteOptions controls scrub-bar sprite sheet generation.
+type SpriteOptions struct {
+	// Columns and Rows size the grid. Zero defaults to 5x5.
+	Columns, Rows int
+	// Width and Height size each individual cell. Zero defaults to 160x90.
+	Width, Height int
+	// SheetURL is the URL the returned sheet will be served from, embedded
+	// into each WebVTT cue so a player can resolve "sprite.jpg#xywh=..."
+	// style references. Empty defaults to the literal "sprite.jpg", which
+	// only resolves for a caller that serves the sheet at that exact path.
+	SheetURL string
+}
+
+// Thumbnailer extracts preview images from a video file identified by its
+// real filesystem path.
+type Thumbnailer interface {
+	// Frame returns a single JPEG frame.
+	Frame(ctx context.Context, path string, opts FrameOptions) ([]byte, error)
+	// Animated returns a short looping WebP clip suitable for hover previews.
+	Animated(ctx context.Context, path string, opts AnimatedOptions) ([]byte, error)
+	// Sprite returns a JPEG grid of sampled frames alongside a WebVTT file
+	// mapping timeline positions to regions within that grid, for use by a
+	// scrub-bar thumbnail player.
+	Sprite(ctx context.Context, path string, opts SpriteOptions) (sheet, vtt []byte, err error)
+}
+
+// FFmpegThumbnailer shells out to the system ffmpeg and ffprobe binaries.
+type FFmpegThumbnailer struct {
+	// Bin is the ffmpeg executable to invoke. Defaults to "ffmpeg" when empty.
+	Bin string
+	// ProbeBin is the ffprobe executable used to look up a video's duration
+	// for Animated and Sprite. Defaults to "ffprobe" when empty.
+	ProbeBin string
+}
+
+// NewFFmpegThumbnailer returns a Thumbnailer backed by the given ffmpeg
+// binary. An empty bin defaults to "ffmpeg" on PATH, and ffprobe is assumed
+// to live alongside it on PATH as well.
+func NewFFmpegThumbnailer(bin string) *FFmpegThumbnailer {
+	return &FFmpegThumbnailer{Bin: bin}
+}
+
+func (t *FFmpegThumbnailer) bin() string {
+	if t.Bin == "" {
+		return "ffmpeg"
+	}
+	return t.Bin
+}
+
+func (t *FFmpegThumbnailer) probeBin() string {
+	if t.ProbeBin == "" {
+		return "ffprobe"
+	}
+	return t.ProbeBin
+}
+
+// probeDuration returns the duration of the video at path via ffprobe, so
+// Animated and Sprite can spread their sampled frames evenly across the
+// whole clip instead of guessing at its frame rate.
+func (t *FFmpegThumbnailer) probeDuration(ctx context.Context, path string) (time.Duration, error) {
+	cmd := exec.CommandContext(ctx, t.probeBin(),
+		"-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", path)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe: duration: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe: parse duration: %w", err)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (t *FFmpegThumbnailer) Frame(ctx context.Context, path string, opts FrameOptions) ([]byte, error) {
+	offset := opts.Offset
+	if offset == 0 {
+		offset = time.Second
+	}
+
+	tmpFile, err := os.CreateTemp("", "video-frame-*.jpg")
+	if err != nil {
+		return nil, err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	cmd := exec.CommandContext(ctx, t.bin(),
+		"-y", "-ss", formatOffset(offset), "-i", path, "-vframes", "1", tmpFile.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: frame extraction: %w: %s", err, output)
+	}
+
+	return os.ReadFile(tmpFile.Name())
+}
+
+func (t *FFmpegThumbnailer) Animated(ctx context.Context, path string, opts AnimatedOptions) ([]byte, error) {
+	frames := opts.Frames
+	if frames == 0 {
+		frames = 10
+	}
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = 320
+	}
+	if height == 0 {
+		height = 200
+	}
+
+	duration, err := t.probeDuration(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpFile, err := os.CreateTemp("", "video-animated-*.webp")
+	if err != nil {
+		return nil, err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	// fps=frames/duration resamples the clip to exactly `frames` frames
+	// spread evenly across its whole length, regardless of the source frame
+	// rate - a fixed frame-index modulus would instead sample every Nth
+	// frame and yield a clip whose length tracks the source's frame count.
+	filter := fmt.Sprintf(
+		"fps=%f,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:-1:-1:color=black",
+		float64(frames)/duration.Seconds(), width, height, width, height,
+	)
+	cmd := exec.CommandContext(ctx, t.bin(),
+		"-y", "-i", path, "-vf", filter, "-vsync", "0", "-loop", "0", tmpFile.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: animated preview: %w: %s", err, output)
+	}
+
+	return os.ReadFile(tmpFile.Name())
+}
+
+func (t *FFmpegThumbnailer) Sprite(ctx context.Context, path string, opts SpriteOptions) ([]byte, []byte, error) {
+	columns, rows := opts.Columns, opts.Rows
+	if columns == 0 {
+		columns = 5
+	}
+	if rows == 0 {
+		rows = 5
+	}
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = 160
+	}
+	if height == 0 {
+		height = 90
+	}
+
+	duration, err := t.probeDuration(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpFile, err := os.CreateTemp("", "video-sprite-*.jpg")
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	// As in Animated, fps=frameCount/duration samples frameCount frames
+	// spread evenly across the whole clip rather than every Nth frame.
+	frameCount := columns * rows
+	filter := fmt.Sprintf(
+		"fps=%f,scale=%d:%d,tile=%dx%d",
+		float64(frameCount)/duration.Seconds(), width, height, columns, rows,
+	)
+	cmd := exec.CommandContext(ctx, t.bin(),
+		"-y", "-i", path, "-frames:v", "1", "-vf", filter, tmpFile.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, nil, fmt.Errorf("ffmpeg: sprite sheet: %w: %s", err, output)
+	}
+
+	sheet, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sheetURL := opts.SheetURL
+	if sheetURL == "" {
+		sheetURL = "sprite.jpg"
+	}
+
+	return sheet, spriteVTT(duration, sheetURL, columns, rows, width, height, frameCount), nil
+}
+
+// spriteVTT builds a WebVTT cue list mapping evenly spaced timeline
+// positions across the video's real duration to regions of the sprite
+// sheet, following the "<sheetURL>#xywh=" media fragment convention used by
+// video.js and similar players.
+func spriteVTT(duration time.Duration, sheetURL string, columns, rows, width, height, frameCount int) []byte {
+	var b bytes.Buffer
+	b.WriteString("WEBVTT\n\n")
+
+	step := duration.Seconds() / float64(frameCount)
+	for i := 0; i < frameCount; i++ {
+		col, row := i%columns, i/columns
+		start, end := float64(i)*step, float64(i+1)*step
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+			i+1, formatVTTTimestamp(start), formatVTTTimestamp(end),
+			sheetURL, col*width, row*height, width, height)
+	}
+
+	return b.Bytes()
+}
+
+func formatOffset(d time.Duration) string {
+	total := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d.000", total/3600, (total%3600)/60, total%60)
+}
+
+func formatVTTTimestamp(seconds float64) string {
+	total := int(seconds)
+	ms := int((seconds - float64(total)) * 1000)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", total/3600, (total%3600)/60, total%60, ms)
+}
+
+// NoopThumbnailer never generates previews; it exists so administrators can
+// disable video thumbnailing entirely without changing call sites.
+type NoopThumbnailer struct{}
+
+func (NoopThumbnailer) Frame(context.Context, string, FrameOptions) ([]byte, error) {
+	return nil, ErrDisabled
+}
+
+func (NoopThumbnailer) Animated(context.Context, string, AnimatedOptions) ([]byte, error) {
+	return nil, ErrDisabled
+}
+
+func (NoopThumbnailer) Sprite(context.Context, string, SpriteOptions) ([]byte, []byte, error) {
+	return nil, nil, ErrDisabled
+}
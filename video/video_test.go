@@ -0,0 +1,55 @@
+package video
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatOffset(t *testing.T) {
+	tests := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{0, "00:00:00.000"},
+		{time.Second, "00:00:01.000"},
+		{90 * time.Second, "00:01:30.000"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "01:02:03.000"},
+	}
+
+	for _, tt := range tests {
+		if got := formatOffset(tt.offset); got != tt.want {
+			t.Errorf("formatOffset(%v) = %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestSpriteVTT(t *testing.T) {
+	vtt := string(spriteVTT(100*time.Second, "/api/preview/big/video.mp4?mode=sprite", 5, 5, 160, 90, 25))
+
+	if !strings.HasPrefix(vtt, "WEBVTT\n\n") {
+		t.Fatalf("missing WEBVTT header: %q", vtt)
+	}
+
+	// 25 cues spread over 100s land on exact 4-second boundaries.
+	if !strings.Contains(vtt, "00:00:00.000 --> 00:00:04.000\n/api/preview/big/video.mp4?mode=sprite#xywh=0,0,160,90") {
+		t.Errorf("first cue not found in: %s", vtt)
+	}
+	if !strings.Contains(vtt, "00:01:36.000 --> 00:01:40.000\n/api/preview/big/video.mp4?mode=sprite#xywh=640,360,160,90") {
+		t.Errorf("last cue not found in: %s", vtt)
+	}
+
+	if got := strings.Count(vtt, "-->"); got != 25 {
+		t.Errorf("got %d cues, want 25", got)
+	}
+}
+
+func TestSpriteVTTScalesToDuration(t *testing.T) {
+	// A shorter clip should produce proportionally shorter cues, not the
+	// same fixed timeline regardless of the video's actual length.
+	vtt := string(spriteVTT(10*time.Second, "sprite.jpg", 5, 5, 160, 90, 25))
+
+	if !strings.Contains(vtt, "00:00:00.000 --> 00:00:00.400") {
+		t.Errorf("cue not scaled to duration: %s", vtt)
+	}
+}